@@ -0,0 +1,104 @@
+// Package cache implements the in-memory token/session store that backs
+// wedlist's magic-link login flow.
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type session struct {
+	userID    int64
+	createdAt time.Time
+	lastSeen  time.Time
+	expireAt  time.Time
+}
+
+// SessionCache maps login tokens (and, once confirmed, session ids) to the
+// user they belong to.
+type SessionCache struct {
+	mu       sync.Mutex
+	sessions map[string]session
+}
+
+// NewSessionCache creates an empty, ready to use SessionCache.
+func NewSessionCache() *SessionCache {
+	return &SessionCache{
+		sessions: make(map[string]session),
+	}
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// Add creates a new token for userID that expires after expireIn and
+// returns it.
+func (sc *SessionCache) Add(userID int64, expireIn time.Duration) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	now := time.Now()
+	sc.sessions[token] = session{
+		userID:    userID,
+		createdAt: now,
+		lastSeen:  now,
+		expireAt:  now.Add(expireIn),
+	}
+
+	return token, nil
+}
+
+// Confirm looks up token and returns the user id it was issued for. It
+// returns -1 if the token is unknown or expired.
+func (sc *SessionCache) Confirm(token string) (int64, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	s, ok := sc.sessions[token]
+	if !ok || time.Now().After(s.expireAt) {
+		return -1, nil
+	}
+
+	s.lastSeen = time.Now()
+	sc.sessions[token] = s
+	return s.userID, nil
+}
+
+// Remove invalidates token, e.g. on logout.
+func (sc *SessionCache) Remove(token string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	delete(sc.sessions, token)
+}
+
+// GC drops every session that expired before now and reports how many
+// were removed.
+func (sc *SessionCache) GC() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	removed := 0
+	now := time.Now()
+	for token, s := range sc.sessions {
+		if now.After(s.expireAt) {
+			delete(sc.sessions, token)
+			removed++
+		}
+	}
+
+	return removed
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// serializeVersion is bumped whenever the on-disk entry layout changes, so
+// Deserialize can reject snapshots it no longer understands.
+const serializeVersion = 1
+
+// Serialize writes every (non-expired or not) session entry in sc to w,
+// length-prefixed and preceded by a version byte. It is meant to be read
+// back with Deserialize across a process restart.
+func (sc *SessionCache) Serialize(w io.Writer) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if _, err := w.Write([]byte{serializeVersion}); err != nil {
+		return err
+	}
+
+	for token, s := range sc.sessions {
+		if err := writeEntry(w, token, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeEntry(w io.Writer, token string, s session) error {
+	if err := writeString(w, token); err != nil {
+		return err
+	}
+
+	fields := []int64{
+		s.userID,
+		s.createdAt.Unix(),
+		s.lastSeen.Unix(),
+		s.expireAt.Unix(),
+	}
+
+	for _, f := range fields {
+		if err := binary.Write(w, binary.BigEndian, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeString(w io.Writer, s string) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(s))); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// Deserialize reads back a snapshot written by Serialize, dropping any
+// entry whose expiry is before now. It replaces sc's current contents.
+func (sc *SessionCache) Deserialize(r io.Reader, now time.Time) error {
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		if err == io.EOF {
+			// Empty snapshot, nothing to load.
+			return nil
+		}
+
+		return err
+	}
+
+	if version[0] != serializeVersion {
+		return fmt.Errorf("unsupported session cache snapshot version: %d", version[0])
+	}
+
+	sessions := make(map[string]session)
+	for {
+		token, err := readString(r)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		s, err := readEntry(r)
+		if err != nil {
+			return err
+		}
+
+		if now.After(s.expireAt) {
+			continue
+		}
+
+		sessions[token] = s
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.sessions = sessions
+	return nil
+}
+
+func readEntry(r io.Reader) (session, error) {
+	var fields [4]int64
+	for i := range fields {
+		if err := binary.Read(r, binary.BigEndian, &fields[i]); err != nil {
+			return session{}, err
+		}
+	}
+
+	return session{
+		userID:    fields[0],
+		createdAt: time.Unix(fields[1], 0),
+		lastSeen:  time.Unix(fields[2], 0),
+		expireAt:  time.Unix(fields[3], 0),
+	}, nil
+}
+
+func readString(r io.Reader) (string, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
@@ -1,42 +1,30 @@
 package db
 
 import (
-	"database/sql"
-	"sync"
+	"errors"
+	"fmt"
+	"time"
 
-	// Load the sqlite3 module, we don't need anything from it.
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/sahib/config"
 )
 
-const (
-	createSQL = `
-PRAGMA foreign_keys = ON;
-
-CREATE TABLE IF NOT EXISTS users(
-    id INTEGER PRIMARY KEY,
-    name TEXT UNIQUE NOT NULL,
-    email TEXT UNIQUE NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS items(
-    id INTEGER PRIMARY KEY,
-    name TEXT NOT NULL,
-    link TEXT NOT NULL,
-	created_by INTEGER NOT NULL,
-	reserved_by INTEGER,
-
-    FOREIGN KEY(reserved_by) REFERENCES users(id),
-    FOREIGN KEY(created_by) REFERENCES users(id)
-);
-`
-)
+// ErrNotAMember is returned by item operations when the calling user is
+// not (or no longer) a member of the list being operated on.
+var ErrNotAMember = errors.New("user is not a member of this list")
 
+// ErrForbidden is returned when the calling user is a member of the list
+// but their role does not permit the operation (e.g. a viewer, or an
+// editor trying to delete an item they did not create).
+var ErrForbidden = errors.New("user is not allowed to perform this action")
+
+// User is a single registered user of wedlist.
 type User struct {
 	ID    int64  `json:"id"`
 	Name  string `json:"name"`
 	EMail string `json:"email"`
 }
 
+// Item is a single wishlist entry.
 type Item struct {
 	ID             int64  `json:"id"`
 	Name           string `json:"name"`
@@ -46,196 +34,81 @@ type Item struct {
 	IsReservedByUs bool   `json:"is_reserved_by_us"`
 }
 
-type Database struct {
-	mu sync.Mutex
-
-	db             *sql.DB
-	userInsertStmt *sql.Stmt
-	itemInsertStmt *sql.Stmt
-	rsrvUpdateStmt *sql.Stmt
-	itemDeleteStmt *sql.Stmt
-}
-
-func NewDatabase(path string) (*Database, error) {
-	db, err := sql.Open("sqlite3", path)
-	if err != nil {
-		return nil, err
-	}
-
-	if _, err := db.Exec(createSQL); err != nil {
-		return nil, err
-	}
-
-	userInsertStmt, err := db.Prepare("INSERT INTO users(name, email) VALUES(?, ?);")
-	if err != nil {
-		return nil, err
-	}
-
-	itemInsertStmt, err := db.Prepare("INSERT INTO items(name, link, created_by, reserved_by) VALUES(?, ?, ?, ?);")
-	if err != nil {
-		return nil, err
-	}
-
-	rsrvUpdateStmt, err := db.Prepare("UPDATE items SET reserved_by = ? WHERE id = ?;")
-	if err != nil {
-		return nil, err
-	}
-
-	itemDeleteStmt, err := db.Prepare("DELETE FROM items WHERE id = ? AND created_by = ?;")
-	if err != nil {
-		return nil, err
-	}
-
-	return &Database{
-		db:             db,
-		userInsertStmt: userInsertStmt,
-		itemInsertStmt: itemInsertStmt,
-		rsrvUpdateStmt: rsrvUpdateStmt,
-		itemDeleteStmt: itemDeleteStmt,
-	}, nil
-}
-
-func (db *Database) Close() error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	return db.db.Close()
-}
-
-func (db *Database) AddUser(name, email string) (int64, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	res, err := db.userInsertStmt.Exec(name, email)
-	if err != nil {
-		return -1, err
-	}
-
-	return res.LastInsertId()
-}
-
-func (db *Database) GetUserByEMail(email string) (*User, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	u := &User{}
-
-	row := db.db.QueryRow("SELECT id, name, email FROM users WHERE email = ?;", email)
-	if err := row.Scan(&u.ID, &u.Name, &u.EMail); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
-		return nil, err
-	}
-
-	return u, nil
-}
-func (db *Database) GetUserByID(ID int64) (*User, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	u := &User{}
-
-	row := db.db.QueryRow("SELECT id, name, email FROM users WHERE id = ?;", ID)
-	if err := row.Scan(&u.ID, &u.Name, &u.EMail); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
-		return nil, err
-	}
-
-	return u, nil
-}
-
-func (db *Database) AddItem(name, link string, createdBy int64, reservedBy int64) (int64, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	res, err := db.itemInsertStmt.Exec(name, link, createdBy, reservedBy)
-	if err != nil {
-		return -1, err
-	}
-
-	return res.LastInsertId()
-}
-
-func (db *Database) DeleteItem(userID, itemID int64) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	_, err := db.itemDeleteStmt.Exec(itemID, userID)
-	return err
-}
-
-func (db *Database) GetItems(userID int64) ([]*Item, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	rows, err := db.db.Query("SELECT id, name, link, created_by, reserved_by FROM items;")
-	if err != nil {
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	items := []*Item{}
-	for rows.Next() {
-		item := &Item{}
-		createdBy := int64(0)
-		reservedBy := sql.NullInt64{}
-		if err := rows.Scan(&item.ID, &item.Name, &item.Link, &createdBy, &reservedBy); err != nil {
-			return nil, err
-		}
-
-		item.IsOwn = userID == createdBy
-		item.IsReserved = reservedBy.Valid
-		item.IsReservedByUs = reservedBy.Valid && reservedBy.Int64 == userID
-		items = append(items, item)
-	}
+// Role is a member's permission level on a List.
+type Role string
 
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
+const (
+	// RoleOwner can manage members and delete any item on the list.
+	RoleOwner Role = "owner"
+	// RoleEditor can add, reserve and delete items.
+	RoleEditor Role = "editor"
+	// RoleViewer can only see items and their reservation state.
+	RoleViewer Role = "viewer"
+)
 
-	return items, nil
+// List is a single shared wishlist, e.g. one couple's wedding registry.
+type List struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   int64     `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Role      Role      `json:"role"`
 }
 
-func (db *Database) Reserve(userID, itemID int64) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	_, err := db.rsrvUpdateStmt.Exec(userID, itemID)
-	return err
+// Store is the interface every SQL backend of wedlist implements.
+// It is the single point the server and its endpoints talk to, so that
+// the concrete `database.driver` (sqlite3 or postgres) stays an
+// implementation detail picked by config.
+type Store interface {
+	Close() error
+
+	AddUser(name, email string) (int64, error)
+	GetUserByEMail(email string) (*User, error)
+	GetUserByID(ID int64) (*User, error)
+
+	// SetPassword stores (or replaces) the password hash for userID.
+	// algo identifies the hashing scheme the hash was produced with.
+	SetPassword(userID int64, hash []byte, algo string) error
+	// GetPasswordHash returns the stored hash and algo for userID, or a
+	// nil hash if the account is link-only (no password set).
+	GetPasswordHash(userID int64) (hash []byte, algo string, err error)
+
+	CreateList(name string, ownerID int64) (int64, error)
+	AddMember(listID, userID int64, role Role) error
+	RemoveMember(listID, userID int64) error
+	ListsForUser(userID int64) ([]*List, error)
+	// IsMember reports whether userID belongs to listID, for callers that
+	// only need a membership check and not the role it comes with.
+	IsMember(listID, userID int64) (bool, error)
+
+	AddItem(name, link string, createdBy int64, reservedBy int64, listID int64) (int64, error)
+	DeleteItem(userID, itemID, listID int64) error
+	GetItems(userID, listID int64) ([]*Item, error)
+
+	Reserve(userID, itemID, listID int64) error
+	Unreserve(userID, itemID, listID int64) error
+	GetUserForReservation(userID, itemID, listID int64) (int64, error)
 }
 
-func (db *Database) Unreserve(itemID int64) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	_, err := db.rsrvUpdateStmt.Exec(sql.NullInt64{}, itemID)
-	return err
+// canMutateItems reports whether role is allowed to add, reserve or
+// unreserve items; only RoleViewer is excluded.
+func canMutateItems(role Role) bool {
+	return role == RoleOwner || role == RoleEditor
 }
 
-func (db *Database) GetUserForReservation(itemID int64) (int64, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
-	row := db.db.QueryRow("SELECT reserved_by FROM items WHERE id = ?;", itemID)
-	reservedBy := sql.NullInt64{}
-	err := row.Scan(&reservedBy)
-	if err == sql.ErrNoRows {
-		return -1, nil
-	}
-
-	if err != nil {
-		return -1, err
-	}
-
-	if !reservedBy.Valid {
-		return -1, nil
+// NewStore opens a Store according to the `database.driver` /
+// `database.dsn` config keys. Supported drivers are "sqlite3" and
+// "postgres".
+func NewStore(cfg *config.Config) (Store, error) {
+	driver := cfg.String("database.driver")
+	dsn := cfg.String("database.dsn")
+
+	switch driver {
+	case "sqlite3":
+		return NewSQLiteStore(dsn)
+	case "postgres":
+		return NewPostgresStore(dsn)
+	default:
+		return nil, fmt.Errorf("unknown database.driver: %q", driver)
 	}
-
-	return reservedBy.Int64, nil
 }
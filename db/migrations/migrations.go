@@ -0,0 +1,103 @@
+// Package migrations implements versioned, ordered SQL schema upgrades for
+// wedlist's sqlite3 and postgres stores. Each schema version lives in its
+// own numbered file (v1.go, v2.go, ...) defining a Migration, mirroring
+// the approach config.NewMigrater takes for the config file itself.
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Migration is a single, numbered step of the schema. Up must bring the
+// schema from Version-1 to Version; Down, if set, must undo it again.
+// dialect is either "sqlite3" or "postgres" so a migration can emit the
+// right DDL for both drivers.
+type Migration struct {
+	Version int
+	Up      func(tx *sql.Tx, dialect string) error
+	Down    func(tx *sql.Tx, dialect string) error
+}
+
+// all is the ordered list of known migrations, lowest version first.
+var all = []Migration{
+	v1,
+	v2,
+	v3,
+}
+
+// CurrentVersion is the newest schema version this binary knows about.
+const CurrentVersion = 3
+
+const schemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations(
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL
+);
+`
+
+// Migrate brings db up to target by running the Up step of every migration
+// between the current on-disk version and target, each inside its own
+// transaction. It only migrates upwards: if the on-disk version is newer
+// than target it returns an error, and Down steps are never run.
+func Migrate(db *sql.DB, dialect string, target int) error {
+	if _, err := db.Exec(schemaMigrationsSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	if current > target {
+		return fmt.Errorf("on-disk schema version %d is newer than target %d; refusing to run an older binary against it", current, target)
+	}
+
+	for _, m := range all {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+
+		if err := applyStep(db, m.Version, m.Up, dialect); err != nil {
+			return fmt.Errorf("migration v%d failed: %w", m.Version, err)
+		}
+	}
+
+	return nil
+}
+
+func currentVersion(db *sql.DB) (int, error) {
+	row := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations;")
+
+	version := 0
+	if err := row.Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func applyStep(db *sql.DB, version int, up func(tx *sql.Tx, dialect string) error, dialect string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := up(tx, dialect); err != nil {
+		tx.Rollback() // nolint: errcheck
+		return err
+	}
+
+	insertSQL := "INSERT INTO schema_migrations(version, applied_at) VALUES(?, CURRENT_TIMESTAMP);"
+	if dialect == "postgres" {
+		insertSQL = "INSERT INTO schema_migrations(version, applied_at) VALUES($1, CURRENT_TIMESTAMP);"
+	}
+
+	if _, err := tx.Exec(insertSQL, version); err != nil {
+		tx.Rollback() // nolint: errcheck
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	// cache=shared keeps every connection *sql.DB opens pointed at the
+	// same in-memory database; a plain ":memory:" DSN would give each
+	// pooled connection its own empty one.
+	db, err := sql.Open("sqlite3", "file::memory:?cache=shared")
+	if err != nil {
+		t.Fatalf("failed to open in-memory sqlite3 db: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() }) // nolint: errcheck
+
+	return db
+}
+
+func TestMigrateCreatesUsersAndItems(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db, "sqlite3", CurrentVersion); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO users(name, email) VALUES('alice', 'alice@example.com');`); err != nil {
+		t.Fatalf("failed to insert into users after migrating: %v", err)
+	}
+}
+
+func TestMigrateBackfillsPreExistingItems(t *testing.T) {
+	db := openTestDB(t)
+
+	// Bring the schema up to v1 only, simulating a deployment that
+	// predates shared lists, and insert data the way that deployment
+	// would have.
+	if err := Migrate(db, "sqlite3", 1); err != nil {
+		t.Fatalf("Migrate to v1 failed: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO users(id, name, email) VALUES(1, 'alice', 'alice@example.com');`); err != nil {
+		t.Fatalf("failed to insert alice: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO users(id, name, email) VALUES(2, 'bob', 'bob@example.com');`); err != nil {
+		t.Fatalf("failed to insert bob: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO items(id, name, link, created_by) VALUES(1, 'toaster', 'http://example.com', 1);`); err != nil {
+		t.Fatalf("failed to insert item: %v", err)
+	}
+
+	// Upgrading to v2 must backfill list_id/list_members in the same
+	// step that adds the column, so no item is ever left unreachable.
+	if err := Migrate(db, "sqlite3", CurrentVersion); err != nil {
+		t.Fatalf("Migrate to current version failed: %v", err)
+	}
+
+	var listID sql.NullInt64
+	row := db.QueryRow(`SELECT list_id FROM items WHERE id = 1;`)
+	if err := row.Scan(&listID); err != nil {
+		t.Fatalf("failed to read back item: %v", err)
+	}
+
+	if !listID.Valid {
+		t.Fatal("item.list_id was not backfilled")
+	}
+
+	for userID, wantRole := range map[int64]string{1: "owner", 2: "editor"} {
+		var role string
+		row := db.QueryRow(`SELECT role FROM list_members WHERE list_id = ? AND user_id = ?;`, listID.Int64, userID)
+		if err := row.Scan(&role); err != nil {
+			t.Fatalf("failed to read back list_members for user %d: %v", userID, err)
+		}
+
+		if role != wantRole {
+			t.Errorf("user %d: got role %q, want %q", userID, role, wantRole)
+		}
+	}
+}
+
+func TestMigrateSkipsBackfillWhenNoItemsExist(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db, "sqlite3", CurrentVersion); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	var listCount int
+	row := db.QueryRow(`SELECT COUNT(*) FROM lists;`)
+	if err := row.Scan(&listCount); err != nil {
+		t.Fatalf("failed to count lists: %v", err)
+	}
+
+	if listCount != 0 {
+		t.Errorf("got %d lists on an empty db, want 0", listCount)
+	}
+}
+
+func TestMigrateRefusesOlderTarget(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db, "sqlite3", CurrentVersion); err != nil {
+		t.Fatalf("Migrate to current version failed: %v", err)
+	}
+
+	if err := Migrate(db, "sqlite3", CurrentVersion-1); err == nil {
+		t.Fatal("expected Migrate to refuse running an older target against a newer on-disk schema, got nil error")
+	}
+}
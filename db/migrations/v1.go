@@ -0,0 +1,39 @@
+package migrations
+
+import "database/sql"
+
+// v1 creates the original users/items tables.
+var v1 = Migration{
+	Version: 1,
+	Up: func(tx *sql.Tx, dialect string) error {
+		idType := "INTEGER PRIMARY KEY"
+		if dialect == "postgres" {
+			idType = "SERIAL PRIMARY KEY"
+		}
+
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS users(
+			id ` + idType + `,
+			name TEXT UNIQUE NOT NULL,
+			email TEXT UNIQUE NOT NULL
+		);`); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS items(
+			id ` + idType + `,
+			name TEXT NOT NULL,
+			link TEXT NOT NULL,
+			created_by INTEGER NOT NULL REFERENCES users(id),
+			reserved_by INTEGER REFERENCES users(id)
+		);`)
+		return err
+	},
+	Down: func(tx *sql.Tx, dialect string) error {
+		if _, err := tx.Exec(`DROP TABLE items;`); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`DROP TABLE users;`)
+		return err
+	},
+}
@@ -0,0 +1,151 @@
+package migrations
+
+import "database/sql"
+
+// v2 introduces shared lists: every item now belongs to exactly one list,
+// and membership in list_members controls who may see or touch it. Items
+// that predate shared lists are backfilled into a new "Default list" in the
+// same step that adds list_id, so nobody is ever locked out of an item they
+// could already see before lists existed.
+var v2 = Migration{
+	Version: 2,
+	Up: func(tx *sql.Tx, dialect string) error {
+		idType := "INTEGER PRIMARY KEY"
+		if dialect == "postgres" {
+			idType = "SERIAL PRIMARY KEY"
+		}
+
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS lists(
+			id ` + idType + `,
+			name TEXT NOT NULL,
+			owner_id INTEGER NOT NULL REFERENCES users(id),
+			created_at TIMESTAMP NOT NULL
+		);`); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS list_members(
+			list_id INTEGER NOT NULL REFERENCES lists(id),
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			role TEXT NOT NULL,
+			PRIMARY KEY(list_id, user_id)
+		);`); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`ALTER TABLE items ADD COLUMN list_id INTEGER REFERENCES lists(id);`); err != nil {
+			return err
+		}
+
+		return backfillDefaultList(tx, dialect)
+	},
+}
+
+// backfillDefaultList assigns every pre-existing item to a new "Default
+// list" and adds every pre-existing user as a member of it, so that
+// upgrading to v2 never leaves an item nobody can reach.
+func backfillDefaultList(tx *sql.Tx, dialect string) error {
+	row := tx.QueryRow(`SELECT COUNT(*) FROM items;`)
+
+	var itemCount int
+	if err := row.Scan(&itemCount); err != nil {
+		return err
+	}
+
+	if itemCount == 0 {
+		return nil
+	}
+
+	row = tx.QueryRow(`SELECT MIN(id) FROM users;`)
+
+	var ownerID sql.NullInt64
+	if err := row.Scan(&ownerID); err != nil {
+		return err
+	}
+
+	if !ownerID.Valid {
+		// Items but no users to own a default list can't actually happen
+		// (items.created_by is NOT NULL), but there is nothing sane to do
+		// here either way.
+		return nil
+	}
+
+	listID, err := insertDefaultList(tx, dialect, ownerID.Int64)
+	if err != nil {
+		return err
+	}
+
+	userRows, err := tx.Query(`SELECT id FROM users;`)
+	if err != nil {
+		return err
+	}
+
+	var userIDs []int64
+	for userRows.Next() {
+		var id int64
+		if err := userRows.Scan(&id); err != nil {
+			userRows.Close()
+			return err
+		}
+
+		userIDs = append(userIDs, id)
+	}
+
+	if err := userRows.Err(); err != nil {
+		userRows.Close()
+		return err
+	}
+
+	userRows.Close()
+
+	memberSQL := `INSERT INTO list_members(list_id, user_id, role) VALUES(?, ?, ?);`
+	if dialect == "postgres" {
+		memberSQL = `INSERT INTO list_members(list_id, user_id, role) VALUES($1, $2, $3);`
+	}
+
+	for _, userID := range userIDs {
+		role := "editor"
+		if userID == ownerID.Int64 {
+			role = "owner"
+		}
+
+		if _, err := tx.Exec(memberSQL, listID, userID, role); err != nil {
+			return err
+		}
+	}
+
+	backfillSQL := `UPDATE items SET list_id = ? WHERE list_id IS NULL;`
+	if dialect == "postgres" {
+		backfillSQL = `UPDATE items SET list_id = $1 WHERE list_id IS NULL;`
+	}
+
+	_, err = tx.Exec(backfillSQL, listID)
+	return err
+}
+
+// insertDefaultList creates the catch-all list pre-existing items are
+// backfilled into and returns its id.
+func insertDefaultList(tx *sql.Tx, dialect string, ownerID int64) (int64, error) {
+	if dialect == "postgres" {
+		var listID int64
+		row := tx.QueryRow(
+			`INSERT INTO lists(name, owner_id, created_at) VALUES($1, $2, CURRENT_TIMESTAMP) RETURNING id;`,
+			"Default list", ownerID,
+		)
+		if err := row.Scan(&listID); err != nil {
+			return -1, err
+		}
+
+		return listID, nil
+	}
+
+	res, err := tx.Exec(
+		`INSERT INTO lists(name, owner_id, created_at) VALUES(?, ?, CURRENT_TIMESTAMP);`,
+		"Default list", ownerID,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.LastInsertId()
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "database/sql"
+
+// v3 adds optional password auth alongside the magic-link flow.
+// password_hash being NULL means the account is still link-only.
+var v3 = Migration{
+	Version: 3,
+	Up: func(tx *sql.Tx, dialect string) error {
+		blobType := "BLOB"
+		if dialect == "postgres" {
+			blobType = "BYTEA"
+		}
+
+		if _, err := tx.Exec(`ALTER TABLE users ADD COLUMN password_hash ` + blobType + `;`); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`ALTER TABLE users ADD COLUMN password_algo TEXT;`)
+		return err
+	},
+}
@@ -0,0 +1,476 @@
+package db
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/sahib/wishlist/db/migrations"
+
+	// Load the sqlite3 module, we don't need anything from it.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteStore is the sqlite3 flavoured Store. It is a thin wrapper around
+// *sql.DB, serialized by a single mutex since sqlite only allows one
+// writer at a time.
+type sqliteStore struct {
+	mu sync.Mutex
+
+	db              *sql.DB
+	userInsertStmt  *sql.Stmt
+	listInsertStmt  *sql.Stmt
+	memberAddStmt   *sql.Stmt
+	memberDropStmt  *sql.Stmt
+	isMemberStmt    *sql.Stmt
+	roleStmt        *sql.Stmt
+	itemInsertStmt  *sql.Stmt
+	rsrvUpdateStmt  *sql.Stmt
+	itemDeleteStmt  *sql.Stmt
+	passwordSetStmt *sql.Stmt
+}
+
+// NewSQLiteStore opens (and if needed creates) a sqlite3 database at path.
+func NewSQLiteStore(path string) (Store, error) {
+	sqlDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := sqlDB.Exec("PRAGMA foreign_keys = ON;"); err != nil {
+		return nil, err
+	}
+
+	if err := migrations.Migrate(sqlDB, "sqlite3", migrations.CurrentVersion); err != nil {
+		return nil, err
+	}
+
+	userInsertStmt, err := sqlDB.Prepare("INSERT INTO users(name, email) VALUES(?, ?);")
+	if err != nil {
+		return nil, err
+	}
+
+	listInsertStmt, err := sqlDB.Prepare("INSERT INTO lists(name, owner_id, created_at) VALUES(?, ?, ?);")
+	if err != nil {
+		return nil, err
+	}
+
+	memberAddStmt, err := sqlDB.Prepare("INSERT OR REPLACE INTO list_members(list_id, user_id, role) VALUES(?, ?, ?);")
+	if err != nil {
+		return nil, err
+	}
+
+	memberDropStmt, err := sqlDB.Prepare("DELETE FROM list_members WHERE list_id = ? AND user_id = ?;")
+	if err != nil {
+		return nil, err
+	}
+
+	isMemberStmt, err := sqlDB.Prepare("SELECT 1 FROM list_members WHERE list_id = ? AND user_id = ?;")
+	if err != nil {
+		return nil, err
+	}
+
+	roleStmt, err := sqlDB.Prepare("SELECT role FROM list_members WHERE list_id = ? AND user_id = ?;")
+	if err != nil {
+		return nil, err
+	}
+
+	itemInsertStmt, err := sqlDB.Prepare("INSERT INTO items(name, link, created_by, reserved_by, list_id) VALUES(?, ?, ?, ?, ?);")
+	if err != nil {
+		return nil, err
+	}
+
+	rsrvUpdateStmt, err := sqlDB.Prepare("UPDATE items SET reserved_by = ? WHERE id = ? AND list_id = ?;")
+	if err != nil {
+		return nil, err
+	}
+
+	itemDeleteStmt, err := sqlDB.Prepare("DELETE FROM items WHERE id = ? AND list_id = ?;")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordSetStmt, err := sqlDB.Prepare("UPDATE users SET password_hash = ?, password_algo = ? WHERE id = ?;")
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqliteStore{
+		db:              sqlDB,
+		userInsertStmt:  userInsertStmt,
+		listInsertStmt:  listInsertStmt,
+		memberAddStmt:   memberAddStmt,
+		memberDropStmt:  memberDropStmt,
+		isMemberStmt:    isMemberStmt,
+		roleStmt:        roleStmt,
+		itemInsertStmt:  itemInsertStmt,
+		rsrvUpdateStmt:  rsrvUpdateStmt,
+		itemDeleteStmt:  itemDeleteStmt,
+		passwordSetStmt: passwordSetStmt,
+	}, nil
+}
+
+func (db *sqliteStore) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.db.Close()
+}
+
+func (db *sqliteStore) AddUser(name, email string) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	res, err := db.userInsertStmt.Exec(name, email)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.LastInsertId()
+}
+
+func (db *sqliteStore) GetUserByEMail(email string) (*User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	u := &User{}
+
+	row := db.db.QueryRow("SELECT id, name, email FROM users WHERE email = ?;", email)
+	if err := row.Scan(&u.ID, &u.Name, &u.EMail); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (db *sqliteStore) GetUserByID(ID int64) (*User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	u := &User{}
+
+	row := db.db.QueryRow("SELECT id, name, email FROM users WHERE id = ?;", ID)
+	if err := row.Scan(&u.ID, &u.Name, &u.EMail); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (db *sqliteStore) SetPassword(userID int64, hash []byte, algo string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.passwordSetStmt.Exec(hash, algo, userID)
+	return err
+}
+
+func (db *sqliteStore) GetPasswordHash(userID int64) ([]byte, string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var hash []byte
+	var algo sql.NullString
+
+	row := db.db.QueryRow("SELECT password_hash, password_algo FROM users WHERE id = ?;", userID)
+	if err := row.Scan(&hash, &algo); err != nil {
+		return nil, "", err
+	}
+
+	return hash, algo.String, nil
+}
+
+func (db *sqliteStore) CreateList(name string, ownerID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	res, err := db.listInsertStmt.Exec(name, ownerID, time.Now())
+	if err != nil {
+		return -1, err
+	}
+
+	listID, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	if _, err := db.memberAddStmt.Exec(listID, ownerID, RoleOwner); err != nil {
+		return -1, err
+	}
+
+	return listID, nil
+}
+
+func (db *sqliteStore) AddMember(listID, userID int64, role Role) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.memberAddStmt.Exec(listID, userID, role)
+	return err
+}
+
+func (db *sqliteStore) RemoveMember(listID, userID int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.memberDropStmt.Exec(listID, userID)
+	return err
+}
+
+func (db *sqliteStore) ListsForUser(userID int64) ([]*List, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rows, err := db.db.Query(`
+		SELECT l.id, l.name, l.owner_id, l.created_at, m.role
+		FROM lists l
+		JOIN list_members m ON m.list_id = l.id
+		WHERE m.user_id = ?;
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	lists := []*List{}
+	for rows.Next() {
+		l := &List{}
+		if err := rows.Scan(&l.ID, &l.Name, &l.OwnerID, &l.CreatedAt, &l.Role); err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+// isMember reports whether userID belongs to listID. db.mu must already be
+// held by the caller.
+func (db *sqliteStore) isMember(listID, userID int64) (bool, error) {
+	row := db.isMemberStmt.QueryRow(listID, userID)
+
+	var ignore int
+	if err := row.Scan(&ignore); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// roleOf returns userID's role on listID, or ErrNotAMember if they don't
+// belong to it. db.mu must already be held by the caller.
+func (db *sqliteStore) roleOf(listID, userID int64) (Role, error) {
+	row := db.roleStmt.QueryRow(listID, userID)
+
+	var role Role
+	if err := row.Scan(&role); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrNotAMember
+		}
+
+		return "", err
+	}
+
+	return role, nil
+}
+
+// itemCreatedBy returns the created_by of itemID within listID, or -1 if no
+// such item exists. db.mu must already be held by the caller.
+func (db *sqliteStore) itemCreatedBy(itemID, listID int64) (int64, error) {
+	row := db.db.QueryRow("SELECT created_by FROM items WHERE id = ? AND list_id = ?;", itemID, listID)
+
+	var createdBy int64
+	if err := row.Scan(&createdBy); err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil
+		}
+
+		return -1, err
+	}
+
+	return createdBy, nil
+}
+
+// IsMember reports whether userID belongs to listID. Unlike isMember it
+// locks db.mu itself, so callers outside this package (e.g. the websocket
+// hub scoping deliveries to list members) can use it directly.
+func (db *sqliteStore) IsMember(listID, userID int64) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.isMember(listID, userID)
+}
+
+func (db *sqliteStore) AddItem(name, link string, createdBy int64, reservedBy int64, listID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	role, err := db.roleOf(listID, createdBy)
+	if err != nil {
+		return -1, err
+	}
+
+	if !canMutateItems(role) {
+		return -1, ErrForbidden
+	}
+
+	// reservedBy <= 0 means "not reserved" and is stored as NULL.
+	reserved := sql.NullInt64{Int64: reservedBy, Valid: reservedBy > 0}
+	res, err := db.itemInsertStmt.Exec(name, link, createdBy, reserved, listID)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.LastInsertId()
+}
+
+func (db *sqliteStore) DeleteItem(userID, itemID, listID int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	role, err := db.roleOf(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	// Owners may delete any item on the list; editors only their own;
+	// viewers may never delete.
+	if role != RoleOwner {
+		createdBy, err := db.itemCreatedBy(itemID, listID)
+		if err != nil {
+			return err
+		}
+
+		if role != RoleEditor || createdBy != userID {
+			return ErrForbidden
+		}
+	}
+
+	_, err = db.itemDeleteStmt.Exec(itemID, listID)
+	return err
+}
+
+func (db *sqliteStore) GetItems(userID, listID int64) ([]*Item, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	isMember, err := db.isMember(listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isMember {
+		return nil, ErrNotAMember
+	}
+
+	rows, err := db.db.Query("SELECT id, name, link, created_by, reserved_by FROM items WHERE list_id = ?;", listID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []*Item{}
+	for rows.Next() {
+		item := &Item{}
+		createdBy := int64(0)
+		reservedBy := sql.NullInt64{}
+		if err := rows.Scan(&item.ID, &item.Name, &item.Link, &createdBy, &reservedBy); err != nil {
+			return nil, err
+		}
+
+		item.IsOwn = userID == createdBy
+		item.IsReserved = reservedBy.Valid
+		item.IsReservedByUs = reservedBy.Valid && reservedBy.Int64 == userID
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (db *sqliteStore) Reserve(userID, itemID, listID int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	role, err := db.roleOf(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !canMutateItems(role) {
+		return ErrForbidden
+	}
+
+	_, err = db.rsrvUpdateStmt.Exec(userID, itemID, listID)
+	return err
+}
+
+func (db *sqliteStore) Unreserve(userID, itemID, listID int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	role, err := db.roleOf(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !canMutateItems(role) {
+		return ErrForbidden
+	}
+
+	_, err = db.rsrvUpdateStmt.Exec(sql.NullInt64{}, itemID, listID)
+	return err
+}
+
+func (db *sqliteStore) GetUserForReservation(userID, itemID, listID int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	isMember, err := db.isMember(listID, userID)
+	if err != nil {
+		return -1, err
+	}
+
+	if !isMember {
+		return -1, ErrNotAMember
+	}
+
+	row := db.db.QueryRow("SELECT reserved_by FROM items WHERE id = ? AND list_id = ?;", itemID, listID)
+	reservedBy := sql.NullInt64{}
+	err = row.Scan(&reservedBy)
+	if err == sql.ErrNoRows {
+		return -1, nil
+	}
+
+	if err != nil {
+		return -1, err
+	}
+
+	if !reservedBy.Valid {
+		return -1, nil
+	}
+
+	return reservedBy.Int64, nil
+}
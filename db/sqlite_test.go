@@ -0,0 +1,120 @@
+package db
+
+import "testing"
+
+// newTestStore opens a throwaway in-memory sqlite3 store, already migrated
+// to the current schema.
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+
+	// A plain ":memory:" DSN gives each pooled connection its own empty
+	// database; cache=shared keeps every connection opened by *sql.DB
+	// pointed at the same one.
+	store, err := NewSQLiteStore("file::memory:?cache=shared")
+	if err != nil {
+		t.Fatalf("failed to open in-memory store: %v", err)
+	}
+
+	t.Cleanup(func() { store.Close() }) // nolint: errcheck
+
+	return store
+}
+
+// setupList creates an owner and a list, adds a second user with role, and
+// returns (listID, ownerID, memberID).
+func setupList(t *testing.T, store Store, role Role) (listID, ownerID, memberID int64) {
+	t.Helper()
+
+	ownerID, err := store.AddUser("alice", "alice@example.com")
+	if err != nil {
+		t.Fatalf("failed to add owner: %v", err)
+	}
+
+	memberID, err = store.AddUser("bob", "bob@example.com")
+	if err != nil {
+		t.Fatalf("failed to add member: %v", err)
+	}
+
+	listID, err = store.CreateList("wedding", ownerID)
+	if err != nil {
+		t.Fatalf("failed to create list: %v", err)
+	}
+
+	if err := store.AddMember(listID, memberID, role); err != nil {
+		t.Fatalf("failed to add member with role %q: %v", role, err)
+	}
+
+	return listID, ownerID, memberID
+}
+
+func TestAddItemRejectsViewer(t *testing.T) {
+	store := newTestStore(t)
+	listID, _, viewerID := setupList(t, store, RoleViewer)
+
+	if _, err := store.AddItem("toaster", "http://example.com", viewerID, 0, listID); err != ErrForbidden {
+		t.Errorf("AddItem by a viewer: got err %v, want ErrForbidden", err)
+	}
+}
+
+func TestAddItemAllowsEditor(t *testing.T) {
+	store := newTestStore(t)
+	listID, _, editorID := setupList(t, store, RoleEditor)
+
+	if _, err := store.AddItem("toaster", "http://example.com", editorID, 0, listID); err != nil {
+		t.Errorf("AddItem by an editor: got err %v, want nil", err)
+	}
+}
+
+func TestAddItemRejectsNonMember(t *testing.T) {
+	store := newTestStore(t)
+	listID, _, _ := setupList(t, store, RoleViewer)
+
+	outsiderID, err := store.AddUser("mallory", "mallory@example.com")
+	if err != nil {
+		t.Fatalf("failed to add outsider: %v", err)
+	}
+
+	if _, err := store.AddItem("toaster", "http://example.com", outsiderID, 0, listID); err != ErrNotAMember {
+		t.Errorf("AddItem by a non-member: got err %v, want ErrNotAMember", err)
+	}
+}
+
+func TestReserveAndUnreserveRejectViewer(t *testing.T) {
+	store := newTestStore(t)
+	listID, ownerID, viewerID := setupList(t, store, RoleViewer)
+
+	itemID, err := store.AddItem("toaster", "http://example.com", ownerID, 0, listID)
+	if err != nil {
+		t.Fatalf("failed to add item as owner: %v", err)
+	}
+
+	if err := store.Reserve(viewerID, itemID, listID); err != ErrForbidden {
+		t.Errorf("Reserve by a viewer: got err %v, want ErrForbidden", err)
+	}
+
+	if err := store.Reserve(ownerID, itemID, listID); err != nil {
+		t.Fatalf("failed to reserve item as owner: %v", err)
+	}
+
+	if err := store.Unreserve(viewerID, itemID, listID); err != ErrForbidden {
+		t.Errorf("Unreserve by a viewer: got err %v, want ErrForbidden", err)
+	}
+}
+
+func TestDeleteItemRejectsViewer(t *testing.T) {
+	store := newTestStore(t)
+	listID, ownerID, viewerID := setupList(t, store, RoleViewer)
+
+	itemID, err := store.AddItem("toaster", "http://example.com", ownerID, 0, listID)
+	if err != nil {
+		t.Fatalf("failed to add item as owner: %v", err)
+	}
+
+	if err := store.DeleteItem(viewerID, itemID, listID); err != ErrForbidden {
+		t.Errorf("DeleteItem by a viewer: got err %v, want ErrForbidden", err)
+	}
+
+	if err := store.DeleteItem(ownerID, itemID, listID); err != nil {
+		t.Errorf("DeleteItem by the owner: got err %v, want nil", err)
+	}
+}
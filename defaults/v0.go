@@ -0,0 +1,84 @@
+package defaults
+
+import "github.com/sahib/config"
+
+// DefaultsV0 is the initial version of wedlist's configuration validation.
+var DefaultsV0 = config.DefaultMapping{
+	"database": config.DefaultMapping{
+		"driver": config.DefaultEntry{
+			Default:      "sqlite3",
+			NeedsRestart: true,
+			Docs:         "Which SQL driver to use: sqlite3 or postgres",
+		},
+		"dsn": config.DefaultEntry{
+			Default:      "wedlist.db",
+			NeedsRestart: true,
+			Docs:         "Data source name passed to sql.Open (a file path for sqlite3, a connection string for postgres)",
+		},
+	},
+	"server": config.DefaultMapping{
+		"port": config.DefaultEntry{
+			Default:      8080,
+			NeedsRestart: true,
+			Docs:         "Port to listen on",
+		},
+		"certfile": config.DefaultEntry{
+			Default:      "",
+			NeedsRestart: true,
+			Docs:         "Path to the TLS certificate file (enables TLS together with server.keyfile)",
+		},
+		"keyfile": config.DefaultEntry{
+			Default:      "",
+			NeedsRestart: true,
+			Docs:         "Path to the TLS key file",
+		},
+		"ws": config.DefaultMapping{
+			"ping_interval": config.DefaultEntry{
+				Default:      "30s",
+				NeedsRestart: true,
+				Docs:         "How often to ping idle websocket clients to keep the connection alive",
+			},
+			"max_conns_per_user": config.DefaultEntry{
+				Default:      10,
+				NeedsRestart: true,
+				Docs:         "Maximum number of simultaneous /api/v0/ws connections a single user may hold open",
+			},
+		},
+	},
+	"auth": config.DefaultMapping{
+		"expire_time": config.DefaultEntry{
+			Default:      "72h",
+			NeedsRestart: false,
+			Docs:         "Time after which a session cookie expires",
+		},
+		"argon2": config.DefaultMapping{
+			"memory_kib": config.DefaultEntry{
+				Default:      65536,
+				NeedsRestart: false,
+				Docs:         "Memory cost (in KiB) for hashing passwords with argon2id",
+			},
+			"time": config.DefaultEntry{
+				Default:      1,
+				NeedsRestart: false,
+				Docs:         "Number of argon2id iterations to run over the memory",
+			},
+			"parallelism": config.DefaultEntry{
+				Default:      4,
+				NeedsRestart: false,
+				Docs:         "Number of parallel threads used by argon2id",
+			},
+		},
+	},
+	"session": config.DefaultMapping{
+		"state_path": config.DefaultEntry{
+			Default:      "",
+			NeedsRestart: true,
+			Docs:         "Path to persist the session cache to, so a restart does not log everyone out. Disabled if empty",
+		},
+		"gc_interval": config.DefaultEntry{
+			Default:      "10m",
+			NeedsRestart: true,
+			Docs:         "How often to evict expired sessions and rewrite the session.state_path snapshot",
+		},
+	},
+}
@@ -0,0 +1,44 @@
+// Package events decouples wedlist's handlers from the transports that
+// deliver item updates to clients. Handlers publish once to a Bus; every
+// registered Sink (the long-poll manager, the websocket hub, ...) gets a
+// copy.
+package events
+
+import "sync"
+
+// Sink receives every event published on a Bus. listID scopes the event to
+// a single shared list, so a Sink can avoid delivering it to users who
+// aren't a member of that list.
+type Sink interface {
+	Publish(listID int64, category string, data interface{})
+}
+
+// Bus fans a single Publish call out to every registered Sink.
+type Bus struct {
+	mu    sync.RWMutex
+	sinks []Sink
+}
+
+// NewBus creates an empty Bus. Use AddSink to register transports before
+// anything starts publishing.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// AddSink registers sink to receive every future Publish call.
+func (b *Bus) AddSink(sink Sink) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sinks = append(b.sinks, sink)
+}
+
+// Publish forwards (listID, category, data) to every registered Sink.
+func (b *Bus) Publish(listID int64, category string, data interface{}) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sink := range b.sinks {
+		sink.Publish(listID, category, data)
+	}
+}
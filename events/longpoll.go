@@ -0,0 +1,55 @@
+package events
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jcuga/golongpoll"
+)
+
+// longpollSink adapts golongpoll's LongpollManager to the Sink interface,
+// so the existing /api/v0/events subscribers keep working unchanged.
+type longpollSink struct {
+	mgr *golongpoll.LongpollManager
+}
+
+// NewLongpollSink wraps mgr so it can be registered on a Bus.
+func NewLongpollSink(mgr *golongpoll.LongpollManager) Sink {
+	return &longpollSink{mgr: mgr}
+}
+
+// listCategory namespaces category by listID, so golongpoll never has to
+// know about lists. This only partitions the namespace: it is not
+// authorization by itself, and relies on the /api/v0/events endpoint
+// checking ParseListCategory's result against real list membership before
+// it lets a client subscribe to one.
+func listCategory(listID int64, category string) string {
+	return fmt.Sprintf("list-%d:%s", listID, category)
+}
+
+// ParseListCategory extracts the list id a category was published under,
+// e.g. "list-3:item_added" -> (3, true). It returns ok=false if category
+// doesn't follow that scheme, which callers should treat as unauthorized
+// rather than letting the subscription through.
+func ParseListCategory(category string) (listID int64, ok bool) {
+	prefix, _, found := strings.Cut(category, ":")
+	if !found || !strings.HasPrefix(prefix, "list-") {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(prefix, "list-"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (s *longpollSink) Publish(listID int64, category string, data interface{}) {
+	cat := listCategory(listID, category)
+	if err := s.mgr.Publish(cat, data); err != nil {
+		log.Printf("warning: failed to publish %q to long-poll subscribers: %v", cat, err)
+	}
+}
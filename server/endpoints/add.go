@@ -0,0 +1,67 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+	"github.com/sahib/wishlist/events"
+)
+
+// AddHandler serves POST on /api/v0/lists/{id}/items, adding a new item to
+// the list on behalf of the calling user.
+type AddHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+	bus   *events.Bus
+}
+
+// NewAddHandler creates an AddHandler backed by store, sessionCache and bus.
+func NewAddHandler(store db.Store, sessionCache *cache.SessionCache, bus *events.Bus) *AddHandler {
+	return &AddHandler{db: store, cache: sessionCache, bus: bus}
+}
+
+func (ah *AddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid list id: %v", err)
+		return
+	}
+
+	user, err := IsAuthenticated(r, ah.cache, ah.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	var body struct {
+		Name string `json:"name"`
+		Link string `json:"link"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+
+	itemID, err := ah.db.AddItem(body.Name, body.Link, user.ID, 0, listID)
+	if err != nil {
+		if err == db.ErrNotAMember || err == db.ErrForbidden {
+			jsonifyErrf(w, http.StatusForbidden, "not allowed to add items to this list")
+			return
+		}
+
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to add item: %v", err)
+		return
+	}
+
+	ah.bus.Publish(listID, "item_added", itemID)
+	json.NewEncoder(w).Encode(map[string]int64{"id": itemID}) // nolint: errcheck
+}
+
+func (ah *AddHandler) NeedsAuthentication() bool {
+	return true
+}
@@ -0,0 +1,98 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+)
+
+// jsonifyErrf writes {"error": "..."} with status to w.
+func jsonifyErrf(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]string{ // nolint: errcheck
+		"error": fmt.Sprintf(format, args...),
+	})
+}
+
+// authAware is implemented by every handler that AuthMiddleware wraps.
+// Most handlers need an authenticated session; a few do not and opt out,
+// either by returning false from NeedsAuthentication (login, register,
+// token) or by being wrapped in NoAuth (the static file server).
+type authAware interface {
+	NeedsAuthentication() bool
+}
+
+type noAuthHandler struct {
+	http.Handler
+}
+
+func (noAuthHandler) NeedsAuthentication() bool {
+	return false
+}
+
+// NoAuth marks handler as reachable without a valid session.
+func NoAuth(handler http.Handler) http.Handler {
+	return noAuthHandler{handler}
+}
+
+// IsAuthenticated resolves the session_id cookie on r, if any, to the
+// db.User it belongs to. It returns (nil, nil) if there is no valid
+// session.
+func IsAuthenticated(r *http.Request, sessionCache *cache.SessionCache, store db.Store) (*db.User, error) {
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		return nil, nil
+	}
+
+	userID, err := sessionCache.Confirm(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID < 0 {
+		return nil, nil
+	}
+
+	return store.GetUserByID(userID)
+}
+
+// AuthMiddleware rejects requests to handlers that need authentication
+// but don't carry a valid session cookie.
+type AuthMiddleware struct {
+	db    db.Store
+	cache *cache.SessionCache
+}
+
+// NewAuthMiddleware creates an AuthMiddleware backed by store and
+// sessionCache.
+func NewAuthMiddleware(store db.Store, sessionCache *cache.SessionCache) *AuthMiddleware {
+	return &AuthMiddleware{db: store, cache: sessionCache}
+}
+
+// Middleware is the gorilla/mux compatible middleware function.
+func (am *AuthMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if aware, ok := next.(authAware); ok && !aware.NeedsAuthentication() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := IsAuthenticated(r, am.cache, am.db)
+		if err != nil {
+			jsonifyErrf(w, http.StatusInternalServerError, "failed to check session: %v", err)
+			return
+		}
+
+		if user == nil {
+			jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
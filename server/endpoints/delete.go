@@ -0,0 +1,66 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+	"github.com/sahib/wishlist/events"
+)
+
+// DelHandler serves DELETE on /api/v0/lists/{id}/items/{item_id}, removing
+// the item if the calling user's role on the list permits it.
+type DelHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+	bus   *events.Bus
+}
+
+// NewDelHandler creates a DelHandler backed by store, sessionCache and bus.
+func NewDelHandler(store db.Store, sessionCache *cache.SessionCache, bus *events.Bus) *DelHandler {
+	return &DelHandler{db: store, cache: sessionCache, bus: bus}
+}
+
+func (dh *DelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	listID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid list id: %v", err)
+		return
+	}
+
+	itemID, err := strconv.ParseInt(vars["item_id"], 10, 64)
+	if err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid item id: %v", err)
+		return
+	}
+
+	user, err := IsAuthenticated(r, dh.cache, dh.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	if err := dh.db.DeleteItem(user.ID, itemID, listID); err != nil {
+		switch err {
+		case db.ErrNotAMember:
+			jsonifyErrf(w, http.StatusForbidden, "not a member of this list")
+		case db.ErrForbidden:
+			jsonifyErrf(w, http.StatusForbidden, "not allowed to delete this item")
+		default:
+			jsonifyErrf(w, http.StatusInternalServerError, "failed to delete item: %v", err)
+		}
+
+		return
+	}
+
+	dh.bus.Publish(listID, "item_deleted", itemID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (dh *DelHandler) NeedsAuthentication() bool {
+	return true
+}
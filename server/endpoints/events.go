@@ -0,0 +1,70 @@
+package endpoints
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jcuga/golongpoll"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+	"github.com/sahib/wishlist/events"
+)
+
+// EventsHandler guards pollMgr's long-poll subscription endpoint: a client
+// may only subscribe to list-<id>:* categories for lists it is actually a
+// member of, mirroring the check ws.Hub.Publish does before fanning an
+// event out over the websocket transport.
+type EventsHandler struct {
+	db      db.Store
+	cache   *cache.SessionCache
+	pollMgr *golongpoll.LongpollManager
+}
+
+// NewEventsHandler creates an EventsHandler backed by store, sessionCache
+// and pollMgr.
+func NewEventsHandler(store db.Store, sessionCache *cache.SessionCache, pollMgr *golongpoll.LongpollManager) *EventsHandler {
+	return &EventsHandler{db: store, cache: sessionCache, pollMgr: pollMgr}
+}
+
+func (eh *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, err := IsAuthenticated(r, eh.cache, eh.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	categories := r.URL.Query()["category"]
+	if cs := r.URL.Query().Get("categories"); cs != "" {
+		categories = append(categories, strings.Split(cs, ",")...)
+	}
+
+	if len(categories) == 0 {
+		jsonifyErrf(w, http.StatusBadRequest, "missing category")
+		return
+	}
+
+	for _, category := range categories {
+		listID, ok := events.ParseListCategory(category)
+		if !ok {
+			jsonifyErrf(w, http.StatusBadRequest, "malformed category: %q", category)
+			return
+		}
+
+		isMember, err := eh.db.IsMember(listID, user.ID)
+		if err != nil {
+			jsonifyErrf(w, http.StatusInternalServerError, "failed to check list membership: %v", err)
+			return
+		}
+
+		if !isMember {
+			jsonifyErrf(w, http.StatusForbidden, "not a member of this list")
+			return
+		}
+	}
+
+	eh.pollMgr.SubscriptionHandler(w, r)
+}
+
+func (eh *EventsHandler) NeedsAuthentication() bool {
+	return true
+}
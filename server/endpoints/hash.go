@@ -0,0 +1,80 @@
+package endpoints
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/sahib/config"
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idAlgo = "argon2id"
+
+// hashPassword derives an argon2id hash for password, using the cost
+// parameters from auth.argon2.*. The returned hash encodes the salt and
+// the cost parameters alongside the derived key, so they can change over
+// time without invalidating existing hashes.
+func hashPassword(cfg *config.Config, password string) (hash []byte, algo string, err error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, "", err
+	}
+
+	memoryKiB := uint32(cfg.Int("auth.argon2.memory_kib"))
+	time := uint32(cfg.Int("auth.argon2.time"))
+	parallelism := uint8(cfg.Int("auth.argon2.parallelism"))
+
+	key := argon2.IDKey([]byte(password), salt, time, memoryKiB, parallelism, 32)
+
+	encoded := fmt.Sprintf(
+		"%d$%d$%d$%s$%s",
+		memoryKiB, time, parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	return []byte(encoded), argon2idAlgo, nil
+}
+
+// verifyPassword checks password against hash, which must have been
+// produced by hashPassword with the same algo.
+func verifyPassword(hash []byte, algo, password string) (bool, error) {
+	if algo != argon2idAlgo {
+		return false, fmt.Errorf("unsupported password algo: %q", algo)
+	}
+
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 5 {
+		return false, fmt.Errorf("malformed password hash")
+	}
+
+	var memoryKiB, time uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[0], "%d", &memoryKiB); err != nil {
+		return false, err
+	}
+
+	if _, err := fmt.Sscanf(parts[1], "%d", &time); err != nil {
+		return false, err
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "%d", &parallelism); err != nil {
+		return false, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, err
+	}
+
+	want, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, err
+	}
+
+	got := argon2.IDKey([]byte(password), salt, time, memoryKiB, parallelism, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
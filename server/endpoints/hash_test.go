@@ -0,0 +1,79 @@
+package endpoints
+
+import (
+	"testing"
+
+	"github.com/sahib/config"
+	"github.com/sahib/wishlist/defaults"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg, err := config.Open(nil, defaults.Defaults, config.StrictnessPanic)
+	if err != nil {
+		t.Fatalf("failed to open default config: %v", err)
+	}
+
+	return cfg
+}
+
+func TestHashAndVerifyPasswordRoundTrip(t *testing.T) {
+	cfg := testConfig(t)
+
+	hash, algo, err := hashPassword(cfg, "hunter2")
+	if err != nil {
+		t.Fatalf("hashPassword failed: %v", err)
+	}
+
+	ok, err := verifyPassword(hash, algo, "hunter2")
+	if err != nil {
+		t.Fatalf("verifyPassword failed: %v", err)
+	}
+
+	if !ok {
+		t.Error("verifyPassword rejected the password it was hashed from")
+	}
+}
+
+func TestVerifyPasswordRejectsWrongPassword(t *testing.T) {
+	cfg := testConfig(t)
+
+	hash, algo, err := hashPassword(cfg, "hunter2")
+	if err != nil {
+		t.Fatalf("hashPassword failed: %v", err)
+	}
+
+	ok, err := verifyPassword(hash, algo, "wrong-password")
+	if err != nil {
+		t.Fatalf("verifyPassword failed: %v", err)
+	}
+
+	if ok {
+		t.Error("verifyPassword accepted a wrong password")
+	}
+}
+
+func TestHashPasswordProducesDistinctSaltsPerCall(t *testing.T) {
+	cfg := testConfig(t)
+
+	hash1, _, err := hashPassword(cfg, "hunter2")
+	if err != nil {
+		t.Fatalf("hashPassword failed: %v", err)
+	}
+
+	hash2, _, err := hashPassword(cfg, "hunter2")
+	if err != nil {
+		t.Fatalf("hashPassword failed: %v", err)
+	}
+
+	if string(hash1) == string(hash2) {
+		t.Error("hashPassword produced identical hashes for two calls with the same password, expected distinct salts")
+	}
+}
+
+func TestVerifyPasswordRejectsUnsupportedAlgo(t *testing.T) {
+	if _, err := verifyPassword([]byte("whatever"), "md5", "hunter2"); err == nil {
+		t.Error("verifyPassword accepted an unsupported algo, expected an error")
+	}
+}
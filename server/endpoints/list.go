@@ -0,0 +1,54 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+)
+
+// ListHandler serves GET on /api/v0/lists/{id}/items, returning every item
+// on the list as seen by the calling user.
+type ListHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+}
+
+// NewListHandler creates a ListHandler backed by store and sessionCache.
+func NewListHandler(store db.Store, sessionCache *cache.SessionCache) *ListHandler {
+	return &ListHandler{db: store, cache: sessionCache}
+}
+
+func (lh *ListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid list id: %v", err)
+		return
+	}
+
+	user, err := IsAuthenticated(r, lh.cache, lh.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	items, err := lh.db.GetItems(user.ID, listID)
+	if err != nil {
+		if err == db.ErrNotAMember {
+			jsonifyErrf(w, http.StatusForbidden, "not a member of this list")
+			return
+		}
+
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to load items: %v", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(items) // nolint: errcheck
+}
+
+func (lh *ListHandler) NeedsAuthentication() bool {
+	return true
+}
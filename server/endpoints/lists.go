@@ -0,0 +1,189 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+)
+
+// ListsHandler serves GET (list the lists the caller belongs to) and POST
+// (create a new list, making the caller its owner) on /api/v0/lists.
+type ListsHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+}
+
+// NewListsHandler creates a ListsHandler backed by store and sessionCache.
+func NewListsHandler(store db.Store, sessionCache *cache.SessionCache) *ListsHandler {
+	return &ListsHandler{db: store, cache: sessionCache}
+}
+
+func (lh *ListsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, err := IsAuthenticated(r, lh.cache, lh.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		lh.handleGet(w, user)
+	case http.MethodPost:
+		lh.handlePost(w, r, user)
+	default:
+		jsonifyErrf(w, http.StatusMethodNotAllowed, "unsupported method: %s", r.Method)
+	}
+}
+
+func (lh *ListsHandler) handleGet(w http.ResponseWriter, user *db.User) {
+	lists, err := lh.db.ListsForUser(user.ID)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to load lists: %v", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(lists) // nolint: errcheck
+}
+
+func (lh *ListsHandler) handlePost(w http.ResponseWriter, r *http.Request, user *db.User) {
+	var body struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+
+	listID, err := lh.db.CreateList(body.Name, user.ID)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to create list: %v", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]int64{"id": listID}) // nolint: errcheck
+}
+
+func (lh *ListsHandler) NeedsAuthentication() bool {
+	return true
+}
+
+// MembersHandler serves POST (add a member) and DELETE (remove a member)
+// on /api/v0/lists/{id}/members. Only owners of the list may manage its
+// members.
+type MembersHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+}
+
+// NewMembersHandler creates a MembersHandler backed by store and
+// sessionCache.
+func NewMembersHandler(store db.Store, sessionCache *cache.SessionCache) *MembersHandler {
+	return &MembersHandler{db: store, cache: sessionCache}
+}
+
+func (mh *MembersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid list id: %v", err)
+		return
+	}
+
+	user, err := IsAuthenticated(r, mh.cache, mh.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	lists, err := mh.db.ListsForUser(user.ID)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to check membership: %v", err)
+		return
+	}
+
+	if !isOwnerOf(lists, listID) {
+		jsonifyErrf(w, http.StatusForbidden, "only the list owner may manage members")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		mh.handleAdd(w, r, listID)
+	case http.MethodDelete:
+		mh.handleRemove(w, r, listID)
+	default:
+		jsonifyErrf(w, http.StatusMethodNotAllowed, "unsupported method: %s", r.Method)
+	}
+}
+
+func (mh *MembersHandler) handleAdd(w http.ResponseWriter, r *http.Request, listID int64) {
+	var body struct {
+		UserID int64   `json:"user_id"`
+		Role   db.Role `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+
+	if !isValidRole(body.Role) {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid role: %q", body.Role)
+		return
+	}
+
+	if err := mh.db.AddMember(listID, body.UserID, body.Role); err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to add member: %v", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// isValidRole reports whether role is one of the roles defined in the db
+// package; AddMember persists whatever it is given, so callers must check
+// this first.
+func isValidRole(role db.Role) bool {
+	switch role {
+	case db.RoleOwner, db.RoleEditor, db.RoleViewer:
+		return true
+	default:
+		return false
+	}
+}
+
+func (mh *MembersHandler) handleRemove(w http.ResponseWriter, r *http.Request, listID int64) {
+	var body struct {
+		UserID int64 `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+
+	if err := mh.db.RemoveMember(listID, body.UserID); err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to remove member: %v", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (mh *MembersHandler) NeedsAuthentication() bool {
+	return true
+}
+
+func isOwnerOf(lists []*db.List, listID int64) bool {
+	for _, l := range lists {
+		if l.ID == listID {
+			return l.Role == db.RoleOwner
+		}
+	}
+
+	return false
+}
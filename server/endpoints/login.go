@@ -0,0 +1,145 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sahib/config"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+)
+
+// LoginHandler serves POST /api/v0/login. With just an email it mints a
+// magic-link token and logs its /api/v0/token link; with an email and a
+// password it verifies the password directly and starts the session on
+// the spot.
+type LoginHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+	cfg   *config.Config
+}
+
+func NewLoginHandler(db db.Store, cache *cache.SessionCache, cfg *config.Config) *LoginHandler {
+	return &LoginHandler{db: db, cache: cache, cfg: cfg}
+}
+
+func (lh *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		EMail    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+
+	if body.Password != "" {
+		lh.handlePasswordLogin(w, body.EMail, body.Password)
+		return
+	}
+
+	user, err := lh.db.GetUserByEMail(body.EMail)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to look up user: %v", err)
+		return
+	}
+
+	if user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "no such user")
+		return
+	}
+
+	lh.handleMagicLinkLogin(w, user)
+}
+
+// handlePasswordLogin verifies password against the stored hash for email
+// and, if it matches, starts the session right away. No such user, a NULL
+// users.password_hash (link-only account) and a wrong password all get the
+// same generic response, so the password branch doesn't reveal which emails
+// are registered or which ones have password auth configured. The
+// magic-link branch in ServeHTTP does answer unknown emails with "no such
+// user".
+func (lh *LoginHandler) handlePasswordLogin(w http.ResponseWriter, email, password string) {
+	user, err := lh.db.GetUserByEMail(email)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to look up user: %v", err)
+		return
+	}
+
+	if user != nil {
+		hash, algo, err := lh.db.GetPasswordHash(user.ID)
+		if err != nil {
+			jsonifyErrf(w, http.StatusInternalServerError, "failed to load password: %v", err)
+			return
+		}
+
+		if hash != nil {
+			ok, err := verifyPassword(hash, algo, password)
+			if err != nil {
+				jsonifyErrf(w, http.StatusInternalServerError, "failed to verify password: %v", err)
+				return
+			}
+
+			if ok {
+				token, err := lh.cache.Add(user.ID, lh.cfg.Duration("auth.expire_time"))
+				if err != nil {
+					jsonifyErrf(w, http.StatusInternalServerError, "failed to start session: %v", err)
+					return
+				}
+
+				setSessionCookies(w, lh.cfg, token, user)
+				return
+			}
+		}
+	}
+
+	jsonifyErrf(w, http.StatusUnauthorized, "invalid credentials")
+}
+
+// handleMagicLinkLogin keeps the original one-shot token flow: a token is
+// minted and handed to the user out of band (e.g. emailed), to be
+// redeemed at /api/v0/token/{token}.
+func (lh *LoginHandler) handleMagicLinkLogin(w http.ResponseWriter, user *db.User) {
+	token, err := lh.cache.Add(user.ID, lh.cfg.Duration("auth.expire_time"))
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to create token: %v", err)
+		return
+	}
+
+	// TODO: actually email the link once we have a mailer; logging it
+	// keeps existing deployments working in the meantime.
+	log.Printf("magic link for %s: /api/v0/token/%s", user.EMail, token)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (lh *LoginHandler) NeedsAuthentication() bool {
+	return false
+}
+
+// setSessionCookies sets the session_id cookie plus the user_name/
+// user_email convenience cookies the frontend reads, all expiring
+// together with auth.expire_time.
+func setSessionCookies(w http.ResponseWriter, cfg *config.Config, token string, user *db.User) {
+	expireTime := time.Now().Add(cfg.Duration("auth.expire_time"))
+	http.SetCookie(w, &http.Cookie{
+		Name:    "session_id",
+		Value:   token,
+		Path:    "/",
+		Expires: expireTime,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:    "user_name",
+		Value:   user.Name,
+		Path:    "/",
+		Expires: expireTime,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:    "user_email",
+		Value:   user.EMail,
+		Path:    "/",
+		Expires: expireTime,
+	})
+}
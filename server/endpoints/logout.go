@@ -0,0 +1,47 @@
+package endpoints
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sahib/wishlist/cache"
+)
+
+// LogoutHandler serves GET /api/v0/logout, invalidating the caller's
+// session and clearing the cookies LoginHandler set.
+type LogoutHandler struct {
+	cache *cache.SessionCache
+}
+
+// NewLogoutHandler creates a LogoutHandler backed by sessionCache.
+func NewLogoutHandler(sessionCache *cache.SessionCache) *LogoutHandler {
+	return &LogoutHandler{cache: sessionCache}
+}
+
+func (lh *LogoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie("session_id"); err == nil {
+		lh.cache.Remove(cookie.Value)
+	}
+
+	clearCookie(w, "session_id")
+	clearCookie(w, "user_name")
+	clearCookie(w, "user_email")
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (lh *LogoutHandler) NeedsAuthentication() bool {
+	return true
+}
+
+// clearCookie overwrites name with an already-expired cookie, so the
+// browser drops it.
+func clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:    name,
+		Value:   "",
+		Path:    "/",
+		Expires: time.Unix(0, 0),
+		MaxAge:  -1,
+	})
+}
@@ -0,0 +1,83 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sahib/config"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+)
+
+// PasswordChangeHandler serves POST /api/v0/password/change. It requires
+// an authenticated session (see AuthMiddleware) and the current password,
+// so a stolen session cookie alone isn't enough to lock the real owner
+// out.
+type PasswordChangeHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+	cfg   *config.Config
+}
+
+func NewPasswordChangeHandler(db db.Store, cache *cache.SessionCache, cfg *config.Config) *PasswordChangeHandler {
+	return &PasswordChangeHandler{db: db, cache: cache, cfg: cfg}
+}
+
+func (ph *PasswordChangeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, err := IsAuthenticated(r, ph.cache, ph.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	var body struct {
+		OldPassword string `json:"old_password"`
+		NewPassword string `json:"new_password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+
+	if body.NewPassword == "" {
+		jsonifyErrf(w, http.StatusBadRequest, "new password must not be empty")
+		return
+	}
+
+	hash, algo, err := ph.db.GetPasswordHash(user.ID)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to load password: %v", err)
+		return
+	}
+
+	if hash != nil {
+		ok, err := verifyPassword(hash, algo, body.OldPassword)
+		if err != nil {
+			jsonifyErrf(w, http.StatusInternalServerError, "failed to verify password: %v", err)
+			return
+		}
+
+		if !ok {
+			jsonifyErrf(w, http.StatusUnauthorized, "wrong password")
+			return
+		}
+	}
+
+	newHash, newAlgo, err := hashPassword(ph.cfg, body.NewPassword)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to hash password: %v", err)
+		return
+	}
+
+	if err := ph.db.SetPassword(user.ID, newHash, newAlgo); err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to store password: %v", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ph *PasswordChangeHandler) NeedsAuthentication() bool {
+	return true
+}
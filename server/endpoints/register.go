@@ -0,0 +1,86 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sahib/config"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+)
+
+// RegisterHandler serves POST /api/v0/register, creating a new user with
+// a password right away instead of waiting for a first magic-link login.
+type RegisterHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+	cfg   *config.Config
+}
+
+func NewRegisterHandler(db db.Store, cache *cache.SessionCache, cfg *config.Config) *RegisterHandler {
+	return &RegisterHandler{db: db, cache: cache, cfg: cfg}
+}
+
+func (rh *RegisterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name     string `json:"name"`
+		EMail    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return
+	}
+
+	if body.Password == "" {
+		jsonifyErrf(w, http.StatusBadRequest, "password must not be empty")
+		return
+	}
+
+	existing, err := rh.db.GetUserByEMail(body.EMail)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to check for existing user: %v", err)
+		return
+	}
+
+	if existing != nil {
+		jsonifyErrf(w, http.StatusConflict, "a user with this email already exists")
+		return
+	}
+
+	userID, err := rh.db.AddUser(body.Name, body.EMail)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to create user: %v", err)
+		return
+	}
+
+	hash, algo, err := hashPassword(rh.cfg, body.Password)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to hash password: %v", err)
+		return
+	}
+
+	if err := rh.db.SetPassword(userID, hash, algo); err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to store password: %v", err)
+		return
+	}
+
+	user, err := rh.db.GetUserByID(userID)
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to load new user: %v", err)
+		return
+	}
+
+	token, err := rh.cache.Add(userID, rh.cfg.Duration("auth.expire_time"))
+	if err != nil {
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to start session: %v", err)
+		return
+	}
+
+	setSessionCookies(w, rh.cfg, token, user)
+}
+
+func (rh *RegisterHandler) NeedsAuthentication() bool {
+	return false
+}
@@ -0,0 +1,106 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+	"github.com/sahib/wishlist/events"
+)
+
+// ReserveHandler serves POST (reserve) and DELETE (unreserve) on
+// /api/v0/lists/{id}/items/{item_id}/reserve.
+type ReserveHandler struct {
+	db    db.Store
+	cache *cache.SessionCache
+	bus   *events.Bus
+}
+
+// NewReserveHandler creates a ReserveHandler backed by store, sessionCache
+// and bus.
+func NewReserveHandler(store db.Store, sessionCache *cache.SessionCache, bus *events.Bus) *ReserveHandler {
+	return &ReserveHandler{db: store, cache: sessionCache, bus: bus}
+}
+
+func (rh *ReserveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	listID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid list id: %v", err)
+		return
+	}
+
+	itemID, err := strconv.ParseInt(vars["item_id"], 10, 64)
+	if err != nil {
+		jsonifyErrf(w, http.StatusBadRequest, "invalid item id: %v", err)
+		return
+	}
+
+	user, err := IsAuthenticated(r, rh.cache, rh.db)
+	if err != nil || user == nil {
+		jsonifyErrf(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		rh.handleReserve(w, user, itemID, listID)
+	case http.MethodDelete:
+		rh.handleUnreserve(w, user, itemID, listID)
+	default:
+		jsonifyErrf(w, http.StatusMethodNotAllowed, "unsupported method: %s", r.Method)
+	}
+}
+
+func (rh *ReserveHandler) handleReserve(w http.ResponseWriter, user *db.User, itemID, listID int64) {
+	if err := rh.db.Reserve(user.ID, itemID, listID); err != nil {
+		if err == db.ErrNotAMember || err == db.ErrForbidden {
+			jsonifyErrf(w, http.StatusForbidden, "not allowed to reserve items on this list")
+			return
+		}
+
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to reserve item: %v", err)
+		return
+	}
+
+	rh.bus.Publish(listID, "item_reserved", itemID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rh *ReserveHandler) handleUnreserve(w http.ResponseWriter, user *db.User, itemID, listID int64) {
+	reservedBy, err := rh.db.GetUserForReservation(user.ID, itemID, listID)
+	if err != nil {
+		if err == db.ErrNotAMember {
+			jsonifyErrf(w, http.StatusForbidden, "not a member of this list")
+			return
+		}
+
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to look up reservation: %v", err)
+		return
+	}
+
+	if reservedBy != user.ID {
+		jsonifyErrf(w, http.StatusForbidden, "item is not reserved by you")
+		return
+	}
+
+	if err := rh.db.Unreserve(user.ID, itemID, listID); err != nil {
+		if err == db.ErrNotAMember || err == db.ErrForbidden {
+			jsonifyErrf(w, http.StatusForbidden, "not allowed to reserve items on this list")
+			return
+		}
+
+		jsonifyErrf(w, http.StatusInternalServerError, "failed to unreserve item: %v", err)
+		return
+	}
+
+	rh.bus.Publish(listID, "item_unreserved", itemID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rh *ReserveHandler) NeedsAuthentication() bool {
+	return true
+}
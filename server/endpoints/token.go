@@ -12,12 +12,12 @@ import (
 )
 
 type TokenHandler struct {
-	db    *db.Database
+	db    db.Store
 	cache *cache.SessionCache
 	cfg   *config.Config
 }
 
-func NewTokenHandler(db *db.Database, cache *cache.SessionCache, cfg *config.Config) *TokenHandler {
+func NewTokenHandler(db db.Store, cache *cache.SessionCache, cfg *config.Config) *TokenHandler {
 	return &TokenHandler{db: db, cache: cache, cfg: cfg}
 }
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/NYTimes/gziphandler"
@@ -14,14 +15,19 @@ import (
 	"github.com/sahib/config"
 	"github.com/sahib/wishlist/cache"
 	"github.com/sahib/wishlist/db"
+	"github.com/sahib/wishlist/events"
 	"github.com/sahib/wishlist/server/endpoints"
+	"github.com/sahib/wishlist/server/ws"
 )
 
 type Server struct {
-	db      *db.Database
-	srv     *http.Server
-	cache   *cache.SessionCache
-	pollMgr *golongpoll.LongpollManager
+	db         db.Store
+	srv        *http.Server
+	cache      *cache.SessionCache
+	pollMgr    *golongpoll.LongpollManager
+	statePath  string
+	gcTicker   *time.Ticker
+	gcStopChan chan struct{}
 }
 
 func getTLSConfig(cfg *config.Config) (*tls.Config, error) {
@@ -44,7 +50,7 @@ func getTLSConfig(cfg *config.Config) (*tls.Config, error) {
 	return nil, nil
 }
 
-func NewServer(cfg *config.Config, db *db.Database, cache *cache.SessionCache) (*Server, error) {
+func NewServer(cfg *config.Config, db db.Store, cache *cache.SessionCache) (*Server, error) {
 	pollMgr, err := golongpoll.StartLongpoll(golongpoll.Options{
 		LoggingEnabled: false,
 	})
@@ -52,15 +58,33 @@ func NewServer(cfg *config.Config, db *db.Database, cache *cache.SessionCache) (
 		return nil, err
 	}
 
+	statePath := cfg.String("session.state_path")
+	if err := loadSessionCache(cache, statePath); err != nil {
+		log.Printf("warning: failed to load session cache from %s: %v", statePath, err)
+	}
+
+	// bus fans every item event out to both transports: the long-poll
+	// manager for old clients, and the websocket hub for new ones.
+	bus := events.NewBus()
+	bus.AddSink(events.NewLongpollSink(pollMgr))
+
+	hub := ws.NewHub(db, int(cfg.Int("server.ws.max_conns_per_user")), cfg.Duration("server.ws.ping_interval"))
+	bus.AddSink(hub)
+
 	router := mux.NewRouter()
-	router.Handle("/api/v0/list", endpoints.NewListHandler(db)).Methods("GET")
-	router.Handle("/api/v0/add", endpoints.NewAddHandler(db, pollMgr)).Methods("POST")
-	router.Handle("/api/v0/delete", endpoints.NewDelHandler(db, pollMgr)).Methods("POST")
-	router.Handle("/api/v0/reserve", endpoints.NewReserveHandler(db, pollMgr)).Methods("POST")
+	router.Handle("/api/v0/lists", endpoints.NewListsHandler(db, cache)).Methods("GET", "POST")
+	router.Handle("/api/v0/lists/{id}/members", endpoints.NewMembersHandler(db, cache)).Methods("POST", "DELETE")
+	router.Handle("/api/v0/lists/{id}/items", endpoints.NewListHandler(db, cache)).Methods("GET")
+	router.Handle("/api/v0/lists/{id}/items", endpoints.NewAddHandler(db, cache, bus)).Methods("POST")
+	router.Handle("/api/v0/lists/{id}/items/{item_id}", endpoints.NewDelHandler(db, cache, bus)).Methods("DELETE")
+	router.Handle("/api/v0/lists/{id}/items/{item_id}/reserve", endpoints.NewReserveHandler(db, cache, bus)).Methods("POST", "DELETE")
 	router.Handle("/api/v0/login", endpoints.NewLoginHandler(db, cache, cfg)).Methods("POST")
+	router.Handle("/api/v0/register", endpoints.NewRegisterHandler(db, cache, cfg)).Methods("POST")
+	router.Handle("/api/v0/password/change", endpoints.NewPasswordChangeHandler(db, cache, cfg)).Methods("POST")
 	router.Handle("/api/v0/logout", endpoints.NewLogoutHandler(cache)).Methods("GET")
 	router.Handle("/api/v0/token/{token}", endpoints.NewTokenHandler(db, cache, cfg)).Methods("GET")
-	router.HandleFunc("/api/v0/events", pollMgr.SubscriptionHandler)
+	router.Handle("/api/v0/events", endpoints.NewEventsHandler(db, cache, pollMgr))
+	router.Handle("/api/v0/ws", endpoints.NoAuth(ws.NewHandler(hub, db, cache)))
 
 	// Redirects to either login or list view:
 	router.Handle("/", endpoints.NoAuth(&indexHandler{db: db, cache: cache}))
@@ -76,9 +100,12 @@ func NewServer(cfg *config.Config, db *db.Database, cache *cache.SessionCache) (
 		log.Printf("warning: failed to load tls config: %v", err)
 	}
 
-	return &Server{
-		db:      db,
-		pollMgr: pollMgr,
+	srv := &Server{
+		db:         db,
+		cache:      cache,
+		pollMgr:    pollMgr,
+		statePath:  statePath,
+		gcStopChan: make(chan struct{}),
 		srv: &http.Server{
 			Addr:              fmt.Sprintf(":%d", cfg.Int("server.port")),
 			Handler:           gziphandler.GzipHandler(router),
@@ -87,11 +114,77 @@ func NewServer(cfg *config.Config, db *db.Database, cache *cache.SessionCache) (
 			IdleTimeout:       360 * time.Second,
 			TLSConfig:         tlsConfig,
 		},
-	}, nil
+	}
+
+	srv.startSessionGC(cfg.Duration("session.gc_interval"))
+	return srv, nil
+}
+
+// loadSessionCache populates cache from the snapshot at statePath, if any.
+// A missing file just means this is the first start.
+func loadSessionCache(cache *cache.SessionCache, statePath string) error {
+	if statePath == "" {
+		return nil
+	}
+
+	fd, err := os.Open(statePath) // #nosec
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	defer fd.Close()
+	return cache.Deserialize(fd, time.Now())
+}
+
+// saveSessionCache snapshots cache to statePath so sessions survive a
+// restart. It is a no-op if session.state_path is unset.
+func saveSessionCache(cache *cache.SessionCache, statePath string) error {
+	if statePath == "" {
+		return nil
+	}
+
+	fd, err := os.Create(statePath) // #nosec
+	if err != nil {
+		return err
+	}
+
+	defer fd.Close()
+	return cache.Serialize(fd)
+}
+
+// startSessionGC runs a background goroutine that periodically evicts
+// expired sessions and rewrites the snapshot, stopped again by Close.
+func (srv *Server) startSessionGC(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	srv.gcTicker = time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-srv.gcTicker.C:
+				removed := srv.cache.GC()
+				if removed > 0 {
+					log.Printf("session cache: evicted %d expired sessions", removed)
+				}
+
+				if err := saveSessionCache(srv.cache, srv.statePath); err != nil {
+					log.Printf("warning: failed to snapshot session cache: %v", err)
+				}
+			case <-srv.gcStopChan:
+				return
+			}
+		}
+	}()
 }
 
 type indexHandler struct {
-	db    *db.Database
+	db    db.Store
 	cache *cache.SessionCache
 }
 
@@ -119,10 +212,20 @@ func (srv *Server) Serve() error {
 func (srv *Server) Terminate() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+
+	if err := saveSessionCache(srv.cache, srv.statePath); err != nil {
+		log.Printf("warning: failed to snapshot session cache: %v", err)
+	}
+
 	return srv.srv.Shutdown(ctx)
 }
 
 func (srv *Server) Close() error {
+	if srv.gcTicker != nil {
+		srv.gcTicker.Stop()
+		close(srv.gcStopChan)
+	}
+
 	srv.pollMgr.Shutdown()
 	return srv.srv.Close()
 }
@@ -0,0 +1,245 @@
+// Package ws implements the websocket push endpoint, a first-class
+// alternative to the long-poll subscription at /api/v0/events.
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sahib/wishlist/cache"
+	"github.com/sahib/wishlist/db"
+	"github.com/sahib/wishlist/server/endpoints"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	maxMsgSize = 1024
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The frontend is served from the same origin as the API, so the
+	// default same-origin check would do; wedlist historically allows
+	// any origin to keep reverse-proxy setups simple.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type client struct {
+	userID int64
+	conn   *websocket.Conn
+	send   chan []byte
+}
+
+// Hub keeps track of every connected websocket client and fans published
+// events out to the ones who belong to the event's list.
+type Hub struct {
+	mu           sync.Mutex
+	clients      map[*client]struct{}
+	byUser       map[int64]int
+	db           db.Store
+	maxPerUser   int
+	pingInterval time.Duration
+}
+
+// NewHub creates a Hub that enforces maxConnsPerUser simultaneous
+// connections per user and pings clients every pingInterval. store is used
+// to check list membership when fanning out a Publish call.
+func NewHub(store db.Store, maxConnsPerUser int, pingInterval time.Duration) *Hub {
+	return &Hub{
+		clients:      make(map[*client]struct{}),
+		byUser:       make(map[int64]int),
+		db:           store,
+		maxPerUser:   maxConnsPerUser,
+		pingInterval: pingInterval,
+	}
+}
+
+// Handler serves the /api/v0/ws endpoint. Authentication is the same
+// session_id cookie the rest of the API uses.
+type Handler struct {
+	hub   *Hub
+	db    db.Store
+	cache *cache.SessionCache
+}
+
+// NewHandler creates a websocket Handler backed by hub.
+func NewHandler(hub *Hub, store db.Store, sessionCache *cache.SessionCache) *Handler {
+	return &Handler{hub: hub, db: store, cache: sessionCache}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, err := endpoints.IsAuthenticated(r, h.cache, h.db)
+	if err != nil || user == nil {
+		http.Error(w, "not logged in", http.StatusUnauthorized)
+		return
+	}
+
+	if !h.hub.reserveSlot(user.ID) {
+		http.Error(w, "too many open connections for this user", http.StatusTooManyRequests)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.hub.releaseSlot(user.ID)
+		log.Printf("ws: upgrade failed: %v", err)
+		return
+	}
+
+	c := &client{userID: user.ID, conn: conn, send: make(chan []byte, 16)}
+	h.hub.register(c)
+
+	go h.hub.writePump(c)
+	h.hub.readPump(c)
+}
+
+func (hub *Hub) reserveSlot(userID int64) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.maxPerUser > 0 && hub.byUser[userID] >= hub.maxPerUser {
+		return false
+	}
+
+	hub.byUser[userID]++
+	return true
+}
+
+func (hub *Hub) releaseSlot(userID int64) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.byUser[userID]--
+	if hub.byUser[userID] <= 0 {
+		delete(hub.byUser, userID)
+	}
+}
+
+func (hub *Hub) register(c *client) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.clients[c] = struct{}{}
+}
+
+func (hub *Hub) unregister(c *client) {
+	hub.mu.Lock()
+	delete(hub.clients, c)
+	// send is closed under the same lock Publish uses to guard its own
+	// send, so Publish never writes to an already-closed channel.
+	close(c.send)
+	hub.mu.Unlock()
+
+	hub.releaseSlot(c.userID)
+	c.conn.Close() // nolint: errcheck
+}
+
+// readPump keeps the connection alive and drops it once the peer goes
+// away; wedlist's websocket protocol is server-to-client only, so incoming
+// data frames are read and discarded. Only pongs extend the read deadline.
+func (hub *Hub) readPump(c *client) {
+	defer hub.unregister(c)
+
+	c.conn.SetReadLimit(maxMsgSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait)) // nolint: errcheck
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait)) // nolint: errcheck
+		return nil
+	})
+
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (hub *Hub) writePump(c *client) {
+	interval := hub.pingInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait)) // nolint: errcheck
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{}) // nolint: errcheck
+				return
+			}
+
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait)) // nolint: errcheck
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// frame is what every client receives, one JSON object per event.
+type frame struct {
+	Type   string      `json:"type"`
+	ItemID int64       `json:"item_id,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// Publish implements events.Sink, broadcasting category as a JSON frame to
+// every connected client who is a member of listID.
+func (hub *Hub) Publish(listID int64, category string, data interface{}) {
+	itemID, _ := data.(int64)
+
+	payload, err := json.Marshal(frame{Type: category, ItemID: itemID, Data: data})
+	if err != nil {
+		log.Printf("ws: failed to marshal event %q: %v", category, err)
+		return
+	}
+
+	hub.mu.Lock()
+	clients := make([]*client, 0, len(hub.clients))
+	for c := range hub.clients {
+		clients = append(clients, c)
+	}
+	hub.mu.Unlock()
+
+	// Membership is checked outside hub.mu: it hits the database per
+	// client, and holding the lock for that would serialize every
+	// register/unregister behind O(N) DB round-trips per published event.
+	for _, c := range clients {
+		isMember, err := hub.db.IsMember(listID, c.userID)
+		if err != nil {
+			log.Printf("ws: failed to check membership for user %d on list %d: %v", c.userID, listID, err)
+			continue
+		}
+
+		if !isMember {
+			continue
+		}
+
+		// Re-taking the lock here is cheap (a map lookup plus a
+		// non-blocking send) and guards against c having unregistered
+		// (and closed c.send) since the snapshot above.
+		hub.mu.Lock()
+		if _, ok := hub.clients[c]; ok {
+			select {
+			case c.send <- payload:
+			default:
+				// Slow client, drop the frame rather than block the publisher.
+			}
+		}
+		hub.mu.Unlock()
+	}
+}